@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"fmt"
+	"hash/crc32"
+)
+
+// Record format versions, written as the first byte of every codec frame so
+// loadDb can tell a legacy fixed-layout file from a framed one without
+// guessing.
+const (
+	formatVersionLegacyFixed byte = 0
+	formatVersionGob         byte = 1
+)
+
+// RecordCodec turns a Record into a self-framed byte slice and back. Each
+// frame is `uint32 length | byte version | payload | uint32 crc32c`, where
+// length covers everything between itself and the checksum (version +
+// payload) - the same convention the WAL frames use. Decode returns how many
+// bytes it consumed so callers can walk a buffer holding several frames back
+// to back. A RecordRepository is built with whatever codec it wants (see
+// NewRecordRepository), so swapping in msgpack/protobuf later is a matter of
+// implementing this interface, not touching storage code.
+type RecordCodec interface {
+	Encode(record Record) ([]byte, error)
+	Decode(data []byte) (record Record, consumed int, err error)
+}
+
+// gobRecordCodec is the default RecordCodec. Unlike the old fixed 98-byte
+// layout, StrValue is unbounded and new Record fields can be added without
+// breaking the on-disk format.
+type gobRecordCodec struct{}
+
+func (gobRecordCodec) Encode(record Record) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(record); err != nil {
+		return nil, err
+	}
+
+	body := make([]byte, 1+buf.Len())
+	body[0] = formatVersionGob
+	copy(body[1:], buf.Bytes())
+
+	frame := make([]byte, 4+len(body)+walCRCSize)
+	binary.LittleEndian.PutUint32(frame, uint32(len(body)))
+	copy(frame[4:], body)
+	binary.LittleEndian.PutUint32(frame[4+len(body):], crc32.Checksum(body, crc32cTable))
+	return frame, nil
+}
+
+func (gobRecordCodec) Decode(data []byte) (Record, int, error) {
+	if len(data) < 4 {
+		return Record{}, 0, fmt.Errorf("truncated record frame")
+	}
+	length := binary.LittleEndian.Uint32(data)
+	total := 4 + int(length) + walCRCSize
+	if len(data) < total {
+		return Record{}, 0, fmt.Errorf("truncated record frame")
+	}
+
+	body := data[4 : 4+length]
+	want := binary.LittleEndian.Uint32(data[4+length : total])
+	if crc32.Checksum(body, crc32cTable) != want {
+		return Record{}, 0, fmt.Errorf("record frame checksum mismatch")
+	}
+
+	version := body[0]
+	if version != formatVersionGob {
+		return Record{}, 0, fmt.Errorf("unsupported record format version %d", version)
+	}
+
+	var record Record
+	if err := gob.NewDecoder(bytes.NewReader(body[1:])).Decode(&record); err != nil {
+		return Record{}, 0, err
+	}
+	return record, total, nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import "sort"
+
+// indexableFields maps a Record field name to an accessor that projects it
+// onto an int64 sort key, so every indexed field - ints, bools, timestamps -
+// can share one sorted-slice implementation instead of a bespoke one each.
+var indexableFields = map[string]func(Record) int64{
+	"ID":        func(r Record) int64 { return r.ID },
+	"IntValue":  func(r Record) int64 { return r.IntValue },
+	"TimeValue": func(r Record) int64 { return r.TimeValue.UnixNano() },
+	"BoolValue": func(r Record) int64 {
+		if r.BoolValue {
+			return 1
+		}
+		return 0
+	},
+}
+
+type indexEntry struct {
+	key int64
+	id  int64
+}
+
+// fieldIndex is a sorted-slice secondary index over one Record field,
+// updated incrementally on add/update/delete instead of being rebuilt on
+// every query. A real b-tree would amortize inserts better, but a sorted
+// slice with binary search is the simplest thing that gives range queries
+// their O(log n + k) lookup, which is all CreateIndex promises here.
+type fieldIndex struct {
+	field   string
+	keyOf   func(Record) int64
+	entries []indexEntry
+}
+
+func newFieldIndex(field string, records []Record) *fieldIndex {
+	keyOf := indexableFields[field]
+	idx := &fieldIndex{field: field, keyOf: keyOf}
+	for _, record := range records {
+		idx.insert(record)
+	}
+	return idx
+}
+
+func (idx *fieldIndex) insert(record Record) {
+	entry := indexEntry{key: idx.keyOf(record), id: record.ID}
+	pos := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= entry.key })
+	idx.entries = append(idx.entries, indexEntry{})
+	copy(idx.entries[pos+1:], idx.entries[pos:])
+	idx.entries[pos] = entry
+}
+
+func (idx *fieldIndex) remove(record Record) {
+	key := idx.keyOf(record)
+	pos := sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= key })
+	for i := pos; i < len(idx.entries) && idx.entries[i].key == key; i++ {
+		if idx.entries[i].id == record.ID {
+			idx.entries = append(idx.entries[:i], idx.entries[i+1:]...)
+			return
+		}
+	}
+}
+
+func (idx *fieldIndex) update(old, updated Record) {
+	idx.remove(old)
+	idx.insert(updated)
+}
+
+// rangeIDs returns the IDs of every entry matching op against value, in key
+// order. Only the range/equality operators make sense against a sorted
+// index; callers should fall back to a full scan for anything else.
+func (idx *fieldIndex) rangeIDs(op string, value int64) []int64 {
+	lo, hi := 0, len(idx.entries)
+	switch op {
+	case "=":
+		lo = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= value })
+		hi = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key > value })
+	case ">":
+		lo = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key > value })
+	case ">=":
+		lo = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= value })
+	case "<":
+		hi = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key >= value })
+	case "<=":
+		hi = sort.Search(len(idx.entries), func(i int) bool { return idx.entries[i].key > value })
+	default:
+		return nil
+	}
+
+	ids := make([]int64, 0, hi-lo)
+	for i := lo; i < hi; i++ {
+		ids = append(ids, idx.entries[i].id)
+	}
+	return ids
+}
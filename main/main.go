@@ -6,22 +6,24 @@ import (
 	"encoding/json"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"net/http"
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
+// BinaryData is implemented by the legacy fixed-size record layout, kept
+// around only so loadDb can read and migrate pre-gob db files.
 type BinaryData interface {
 	write(file *os.File) error
 	read(recordBytes []byte) error
 }
 
-const recordSize = 8 + 8 + 64 + 1 + 16 + 1
+const legacyRecordSize = 8 + 8 + 64 + 1 + 16 + 1
 
 type Record struct {
 	ID        int64     `json:"ID"`
@@ -31,8 +33,10 @@ type Record struct {
 	TimeValue time.Time `json:"TimeValue"`
 }
 
-func (r *Record) write(file *os.File) error {
-
+// legacyEncode renders the record using the fixed 98-byte on-disk layout
+// used before the gobRecordCodec, kept only so legacy db files can still be
+// read and migrated.
+func (r *Record) legacyEncode() ([]byte, error) {
 	buffer := make([]byte, 98)
 
 	binary.LittleEndian.PutUint64(buffer, uint64(r.ID))
@@ -48,16 +52,25 @@ func (r *Record) write(file *os.File) error {
 
 	timeBytes, err := r.TimeValue.MarshalBinary()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	copy(buffer[81:], timeBytes)
 
 	buffer[len(buffer)-1] = '\n'
+	return buffer, nil
+}
 
+func (r *Record) write(file *os.File) error {
+	buffer, err := r.legacyEncode()
+	if err != nil {
+		return err
+	}
 	_, err = file.Write(buffer)
 	return err
 }
 
+// read parses the legacy fixed 98-byte layout. Only used by the migration
+// path in loadSnapshot; current db files are read through RecordCodec.
 func (r *Record) read(recordBytes []byte) error {
 	reader := bytes.NewReader(recordBytes)
 	if err := binary.Read(reader, binary.LittleEndian, &r.ID); err != nil {
@@ -86,11 +99,11 @@ func (r *Record) read(recordBytes []byte) error {
 		return err
 	}
 
-	// Remove trailing 0x00 bytes
-	timeBytes = bytes.TrimRight(timeBytes, string([]byte{0}))
-
-	// Unmarshal the binary data into time.Time
-	if err := r.TimeValue.UnmarshalBinary(timeBytes); err != nil {
+	// time.Time.MarshalBinary always writes a fixed 15 bytes; the 16th byte
+	// of this field is legacyEncode's padding, not part of the value, and
+	// trimming trailing zeros instead would corrupt any time whose real
+	// encoding happens to end in a zero byte (e.g. a UTC offset of 0).
+	if err := r.TimeValue.UnmarshalBinary(timeBytes[:15]); err != nil {
 		return err
 	}
 	return nil
@@ -101,198 +114,471 @@ type RecordRepository interface {
 	read(id int64) (Record, error)
 	update(record Record, id int64) error
 	delete(id int64) error
+	List(query Query) ([]Record, int64, error)
+	CreateIndex(field string) error
 	printDb()
 	saveDb() error
 	loadDb() error
 }
 
 type RecordRepositoryImpl struct {
-	lock           sync.Mutex
-	records        []Record
-	deletedIndexes []int64
-	realFilePath   *string
+	shards       [numShards]*shard
+	ids          idAllocator
+	realFilePath *string
+	wal          *WAL
+	walPath      string
+	snapPath     string
+	snapshotLSN  uint64
+	codec        RecordCodec
+	indexMu      sync.RWMutex
+	indexes      map[string]*fieldIndex
+	saveMu       sync.Mutex
+}
+
+// NewRecordRepository builds a RecordRepositoryImpl backed by dbFile, using
+// codec to frame records on disk and in the WAL. A nil codec defaults to
+// gobRecordCodec{}.
+func NewRecordRepository(dbFile string, codec RecordCodec) *RecordRepositoryImpl {
+	if codec == nil {
+		codec = gobRecordCodec{}
+	}
+	return &RecordRepositoryImpl{
+		shards:       newShards(),
+		realFilePath: &dbFile,
+		codec:        codec,
+	}
+}
+
+// allRecords snapshots every shard under an RLock, used by saveDb to
+// serialize the current state.
+func (f *RecordRepositoryImpl) allRecords() []Record {
+	var all []Record
+	for _, sh := range f.shards {
+		sh.mu.RLock()
+		for _, record := range sh.records {
+			all = append(all, record)
+		}
+		sh.mu.RUnlock()
+	}
+	return all
 }
 
+// walAndSnapPaths derives the WAL and snapshot file paths from the
+// configured db file's directory, so `--db_file ./data/records.bin` keeps
+// everything under `./data/`.
+func walAndSnapPaths(dbFile string) (walPath, snapPath string) {
+	dir := filepath.Dir(dbFile)
+	return filepath.Join(dir, "records.wal"), filepath.Join(dir, "records.snap")
+}
+
+// saveDb snapshots the current in-memory state to f.snapPath and truncates
+// the WAL of every entry already captured by the snapshot. It used to
+// rewrite the whole db file from scratch on every tick; now it only runs
+// periodically as compaction, since add/update/delete are durable the
+// moment they return (see append to f.wal).
+//
+// The LSN is captured before allRecords scans the shards, not after: reading
+// it after would let a mutation that commits to a shard already scanned (but
+// before the LSN read) land in the snapshot while still being at or below
+// the LSN we then truncate the WAL to - discarding the only durable copy of
+// it. Capturing the LSN first guarantees every such mutation has an LSN
+// greater than what's truncated, so at worst it's replayed once from the
+// snapshot and once from the WAL; applyRecord/applyDelete are idempotent,
+// so a harmless double-apply beats a silent loss.
+//
+// saveMu serializes the whole function against itself: it's called both
+// from the periodic compaction goroutine and, on demand, from
+// syncBlocksHandler, and saveDb has no other way to stop two concurrent
+// runs from both creating f.snapPath+".tmp", interleaving writes into it,
+// and racing the rename/truncate that follow.
 func (f *RecordRepositoryImpl) saveDb() error {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	_, err := os.Stat(*f.realFilePath)
+	f.saveMu.Lock()
+	defer f.saveMu.Unlock()
 
-	//check if file exists, create new if not
-	var file *os.File
-	if err == nil {
-		file, err = os.OpenFile(*f.realFilePath, os.O_WRONLY, os.ModeAppend)
-	} else if os.IsNotExist(err) {
-		file, err = os.Create(*f.realFilePath)
+	lsn := f.snapshotLSN
+	if f.wal != nil {
+		lsn = f.wal.currentLSN()
 	}
+	records := f.allRecords()
 
+	tmpPath := f.snapPath + ".tmp"
+	file, err := os.Create(tmpPath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-	for i := range f.records {
-		err := f.records[i].write(file)
+
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint64(header, lsn)
+	if _, err := file.Write(header); err != nil {
+		file.Close()
+		return err
+	}
+	for i := range records {
+		frame, err := f.codec.Encode(records[i])
 		if err != nil {
+			file.Close()
 			return err
 		}
+		if _, err := file.Write(frame); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, f.snapPath); err != nil {
+		return err
+	}
+
+	f.snapshotLSN = lsn
+	if f.wal != nil {
+		return f.wal.truncateBefore(lsn)
 	}
 	return nil
 }
 
+// loadDb restores state from the last snapshot (if any) and then replays
+// every WAL entry appended after that snapshot's LSN, so the in-memory
+// slice reflects every mutation that was ever fsynced, crash or not.
 func (f *RecordRepositoryImpl) loadDb() error {
 	if f.realFilePath == nil {
 		return nil
 	}
-	file, err := os.Open(*f.realFilePath)
-	if os.IsNotExist(err) {
+	f.walPath, f.snapPath = walAndSnapPaths(*f.realFilePath)
+
+	if err := f.loadSnapshot(); err != nil {
+		return err
+	}
+
+	wal, err := openWAL(f.walPath)
+	if err != nil {
+		return err
+	}
+	f.wal = wal
+	wal.setLSN(f.snapshotLSN)
+
+	return wal.replay(func(opcode walOpcode, lsn uint64, payload []byte) error {
+		switch opcode {
+		case walOpAdd, walOpUpdate:
+			record, _, err := f.codec.Decode(payload)
+			if err != nil {
+				return fmt.Errorf("error loading data: %w", err)
+			}
+			f.applyRecord(record)
+		case walOpDelete:
+			id, err := decodeIDPayload(payload)
+			if err != nil {
+				return err
+			}
+			f.applyDelete(id)
+		default:
+			return fmt.Errorf("unknown WAL opcode %d", opcode)
+		}
 		return nil
+	})
+}
+
+func (f *RecordRepositoryImpl) loadSnapshot() error {
+	raw, err := os.ReadFile(f.snapPath)
+	if os.IsNotExist(err) {
+		return f.loadLegacyDbFile()
 	} else if err != nil {
 		return err
 	}
-	defer file.Close()
+	if len(raw) < 8 {
+		return nil
+	}
+	f.snapshotLSN = binary.LittleEndian.Uint64(raw[:8])
+	body := raw[8:]
+
+	offset := 0
+	for offset < len(body) {
+		record, consumed, err := f.codec.Decode(body[offset:])
+		if err != nil {
+			return fmt.Errorf("error loading data: %w", err)
+		}
+		f.applyRecord(record)
+		offset += consumed
+	}
+	return nil
+}
 
-	buffer := make([]byte, recordSize)
+// loadLegacyDbFile handles the one-time upgrade of a pre-WAL deployment: if
+// there's no records.snap yet, the configured --db_file may still be the old
+// fixed-layout file holding every record the repository has ever seen, with
+// no 8-byte LSN header of its own. Detection and migration both run against
+// its raw bytes directly; a missing or non-legacy file just means a fresh
+// start, not an error.
+func (f *RecordRepositoryImpl) loadLegacyDbFile() error {
+	raw, err := os.ReadFile(*f.realFilePath)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !isLegacyFixedSnapshot(raw) {
+		return nil
+	}
+	return f.migrateLegacySnapshot(raw)
+}
 
-	iterator := 0
-	for {
-		// Read a chunk of data into the buffer
-		iterator++
-		n, err := file.Read(buffer)
-		if err == io.EOF {
-			// EOF (end of file) reached
-			break
-		} else if err != nil {
-			log.Fatal(err)
+// isLegacyFixedSnapshot reports whether body looks like the pre-gob
+// fixed-98-byte-per-record layout rather than a run of versioned codec
+// frames: its length must be an exact multiple of legacyRecordSize, and
+// every record boundary must land on the '\n' legacyEncode always writes as
+// its last byte - a run of real codec frames landing on that byte at every
+// one of those offsets by chance is effectively impossible.
+func isLegacyFixedSnapshot(body []byte) bool {
+	if len(body) == 0 || len(body)%legacyRecordSize != 0 {
+		return false
+	}
+	for offset := legacyRecordSize - 1; offset < len(body); offset += legacyRecordSize {
+		if body[offset] != '\n' {
+			return false
 		}
+	}
+	return true
+}
 
-		// Process the chunk of data (use the first 'n' bytes of the buffer)
+// migrateLegacySnapshot reads every record out of the old fixed-size
+// snapshot, rewrites it through the configured codec into a `.migrated`
+// sidecar, and atomically swaps it in for f.snapPath - so a pre-gob db file
+// is upgraded transparently on first load instead of breaking.
+func (f *RecordRepositoryImpl) migrateLegacySnapshot(body []byte) error {
+	legacyBuffer := make([]byte, legacyRecordSize)
+	for offset := 0; offset < len(body); offset += legacyRecordSize {
+		copy(legacyBuffer, body[offset:offset+legacyRecordSize])
 		record := Record{}
-		err = record.read((buffer[:n]))
+		if err := record.read(legacyBuffer); err != nil {
+			return fmt.Errorf("error loading data: %w", err)
+		}
+
+		// The legacy layout kept deleted records as an ID-zeroed slot at a
+		// fixed position; its ID was that position (1-based), same as the
+		// live repository computed at the time.
+		id := int64(offset/legacyRecordSize) + 1
 		if record.ID == 0 {
-			f.deletedIndexes = append(f.deletedIndexes, int64(iterator)-1)
+			f.ids.release(id)
+			continue
 		}
+		f.applyRecord(record)
+	}
+
+	migratedPath := f.snapPath + ".migrated"
+	file, err := os.Create(migratedPath)
+	if err != nil {
+		return err
+	}
+
+	records := f.allRecords()
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint64(header, f.snapshotLSN)
+	if _, err := file.Write(header); err != nil {
+		file.Close()
+		return err
+	}
+	for i := range records {
+		frame, err := f.codec.Encode(records[i])
 		if err != nil {
-			return fmt.Errorf("error loading data")
+			file.Close()
+			return err
+		}
+		if _, err := file.Write(frame); err != nil {
+			file.Close()
+			return err
 		}
-		f.records = append(f.records, record)
 	}
-	return nil
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(migratedPath, f.snapPath)
+}
+
+// applyRecord places a replayed add/update into its ID's shard, used during
+// WAL replay and legacy migration. IDs are first-class here: the shard a
+// record lives in is derived from record.ID itself, never from its position
+// in some slice.
+func (f *RecordRepositoryImpl) applyRecord(record Record) {
+	sh := shardFor(f.shards, record.ID)
+	sh.records[record.ID] = record
+	f.ids.observe(record.ID)
+}
+
+func (f *RecordRepositoryImpl) applyDelete(id int64) {
+	sh := shardFor(f.shards, id)
+	delete(sh.records, id)
+	f.ids.release(id)
 }
 
 func (f *RecordRepositoryImpl) printDb() {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	for i := range f.records {
-		fmt.Println(f.records[i])
+	for _, record := range f.allRecords() {
+		fmt.Println(record)
 	}
 }
 
 func (f *RecordRepositoryImpl) add(record Record) (int64, error) {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	var i int
-	var replace bool
-	if len(f.deletedIndexes) == 0 {
-		i = len(f.records) + 1
-		replace = false
-	} else {
-		i = int(f.deletedIndexes[0])
-		f.deletedIndexes = f.deletedIndexes[1:]
-		replace = true
-	}
+	id := f.ids.allocate()
+	record.ID = id
 
-	record.ID = int64(i + 1)
-	if replace {
-		f.records[i] = record
-	} else {
-		f.records = append(f.records, record)
+	sh := shardFor(f.shards, id)
+	sh.mu.Lock()
+	sh.records[id] = record
+	err := f.appendWAL(walOpAdd, record)
+	if err != nil {
+		delete(sh.records, id)
+	}
+	sh.mu.Unlock()
+	if err != nil {
+		f.ids.release(id)
+		return 0, err
 	}
-	return record.ID, nil
+	f.updateIndexes(nil, &record)
+	replicateMutation(walOpAdd, record)
+	return id, nil
 }
 
 func (f *RecordRepositoryImpl) read(id int64) (Record, error) {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	if !f.canExist(id) {
-		return Record{}, fmt.Errorf("NOT_FOUND")
-	}
-	record := f.records[id]
-	if record.ID == 0 {
+	sh := shardFor(f.shards, id)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+	record, ok := sh.records[id]
+	if !ok {
 		return Record{}, fmt.Errorf("NOT_FOUND")
 	}
 	return record, nil
 }
 
-func (f *RecordRepositoryImpl) canExist(id int64) bool {
-	if id < 0 || id >= int64(len(f.records)) {
-		return false
-	}
-	return true
-}
-
 func (f *RecordRepositoryImpl) update(record Record, id int64) error {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	record.ID = id
-	if !f.canExist(id) {
+	sh := shardFor(f.shards, id)
+	sh.mu.Lock()
+	old, ok := sh.records[id]
+	if !ok {
+		sh.mu.Unlock()
 		return fmt.Errorf("NOT_FOUND")
 	}
-	f.records[id-1] = record
+	record.ID = id
+	sh.records[id] = record
+	err := f.appendWAL(walOpUpdate, record)
+	sh.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	f.updateIndexes(&old, &record)
+	replicateMutation(walOpUpdate, record)
 	return nil
 }
 
 func (f *RecordRepositoryImpl) delete(id int64) error {
-	f.lock.Lock()
-	defer f.lock.Unlock()
-	if !f.canExist(id) || f.records[id].ID == 0 {
+	sh := shardFor(f.shards, id)
+	sh.mu.Lock()
+	old, ok := sh.records[id]
+	if !ok {
+		sh.mu.Unlock()
 		return fmt.Errorf("NOT_FOUND")
 	}
-	f.records[id].ID = 0
-	f.deletedIndexes = append(f.deletedIndexes, id)
+	delete(sh.records, id)
+	f.ids.release(id)
+
+	var err error
+	if f.wal != nil {
+		_, err = f.wal.append(walOpDelete, encodeIDPayload(id))
+	}
+	sh.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	f.updateIndexes(&old, nil)
+	replicateDelete(id)
 	return nil
 }
 
+// appendWAL fsyncs an add/update mutation before the caller's HTTP handler
+// returns, so an acknowledged write can never be lost to a crash. The WAL
+// serializes its own appends, so this needs no lock of its own - callers
+// just need to hold their shard's lock while the record itself is mutated.
+func (f *RecordRepositoryImpl) appendWAL(opcode walOpcode, record Record) error {
+	if f.wal == nil {
+		return nil
+	}
+	payload, err := f.codec.Encode(record)
+	if err != nil {
+		return err
+	}
+	_, err = f.wal.append(opcode, payload)
+	return err
+}
+
 var FileDB RecordRepositoryImpl
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "sync" {
+		if err := runSyncCommand(os.Args[2:]); err != nil {
+			fmt.Println("sync failed:", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	debug := flag.Bool("debug", true, "sets log level to debug")
 	dbFile := flag.String("db_file", "./records.bin", "path to db file")
+	nodeID := flag.String("node-id", "", "node id; enables replication when set")
+	raftAddr := flag.String("raft-addr", "", "this node's own base URL, e.g. http://localhost:8080")
+	raftLeader := flag.Bool("raft-leader", false, "whether this node is the replication leader")
+	peers := flag.String("peers", "", "comma-separated peer base URLs (the leader's followers, or just the leader for a follower)")
+	index := flag.String("index", "", "comma-separated fields to build a secondary index over, e.g. IntValue,TimeValue")
 
 	flag.Parse()
 
-	FileDB = RecordRepositoryImpl{
-		lock:           sync.Mutex{},
-		records:        make([]Record, 0),
-		realFilePath:   dbFile,
-		deletedIndexes: make([]int64, 0),
+	if *nodeID != "" {
+		var peerList []string
+		if *peers != "" {
+			peerList = strings.Split(*peers, ",")
+		}
+		Raft = newReplicationNode(*nodeID, *raftAddr, peerList, *raftLeader)
+		fmt.Println("WARNING: replication is a best-effort operator-designated leader/follower setup, not Raft - " +
+			"no election, no quorum commit, a partitioned leader keeps accepting writes. See replication.go for details.")
 	}
 
+	FileDB = *NewRecordRepository(*dbFile, nil)
+
 	abs, err := filepath.Abs(*FileDB.realFilePath)
 	if err != nil {
 		panic(err)
 	}
 	fmt.Println("dbFile", abs)
 	FileDB.loadDb()
+
+	if *index != "" {
+		for _, field := range strings.Split(*index, ",") {
+			if err := FileDB.CreateIndex(field); err != nil {
+				log.Fatal(err)
+			}
+			fmt.Println("indexed", field)
+		}
+	}
+
 	if *debug {
 		fmt.Println("---INIT STATE---")
 		FileDB.printDb()
 		fmt.Println("---INIT STATE---")
 	}
 
-	http.HandleFunc("/records", createRecord)
-	http.HandleFunc("/records/", func(w http.ResponseWriter, r *http.Request) {
-		switch r.Method {
-		case http.MethodGet:
-			getRecordByID(w, r)
-		case http.MethodPut:
-			updateRecordByID(w, r)
-		case http.MethodDelete:
-			deleteRecordByID(w, r)
-		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
-		}
-	})
+	http.HandleFunc("/records", recordsHandler)
+	http.HandleFunc("/records/", recordByIDHandler)
+	http.HandleFunc("/sync/blocks", syncBlocksHandler)
+	http.HandleFunc("/sync/block", syncBlockHandler)
+	http.HandleFunc("/raft/apply", raftApplyHandler)
+	http.HandleFunc("/raft/join", raftJoinHandler)
 	http.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodGet {
 			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
@@ -318,9 +604,13 @@ func main() {
 		}
 	}()
 
+	// Compaction no longer rewrites the whole db on every tick: mutations are
+	// already durable via the WAL the instant add/update/delete return. This
+	// goroutine just periodically folds the WAL into a snapshot and truncates
+	// it, so the log doesn't grow forever.
 	go func() {
 		for {
-			time.Sleep(500 * time.Millisecond)
+			time.Sleep(5 * time.Second)
 			err := FileDB.saveDb()
 			if err != nil {
 				log.Fatal(err)
@@ -346,13 +636,33 @@ func getId(w http.ResponseWriter, r *http.Request) (int64, error) {
 	return parseInt, nil
 }
 
+// recordByIDHandler dispatches /records/{id} by method; split out of main()
+// so tests can register it against an httptest.Server without starting a
+// real listener.
+func recordByIDHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		getRecordByID(w, r)
+	case http.MethodPut:
+		updateRecordByID(w, r)
+	case http.MethodDelete:
+		deleteRecordByID(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
 func deleteRecordByID(w http.ResponseWriter, r *http.Request) {
+	if Raft.redirectToLeader(w, r) {
+		return
+	}
+
 	id, err := getId(w, r)
 	if err != nil {
 		return
 	}
 
-	err = FileDB.delete(id - 1)
+	err = FileDB.delete(id)
 	if err != nil {
 		handleStorageError(w, err)
 		return
@@ -362,11 +672,14 @@ func deleteRecordByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func updateRecordByID(w http.ResponseWriter, r *http.Request) {
+	if Raft.redirectToLeader(w, r) {
+		return
+	}
+
 	id, err := getId(w, r)
 	if err != nil {
 		return
 	}
-	fmt.Println(id)
 
 	var updateRecord Record
 	if err := json.NewDecoder(r.Body).Decode(&updateRecord); err != nil {
@@ -374,7 +687,7 @@ func updateRecordByID(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = FileDB.update(updateRecord, id-1)
+	err = FileDB.update(updateRecord, id)
 	if err != nil {
 		handleStorageError(w, err)
 		return
@@ -383,11 +696,15 @@ func updateRecordByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func getRecordByID(w http.ResponseWriter, r *http.Request) {
+	if consistencyFor(r) == "leader" && Raft.redirectToLeader(w, r) {
+		return
+	}
+
 	id, err := getId(w, r)
 	if err != nil {
 		return
 	}
-	read, err := FileDB.read(id - 1)
+	read, err := FileDB.read(id)
 	if err != nil {
 		handleStorageError(w, err)
 		return
@@ -404,14 +721,15 @@ func getRecordByID(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Internal server error", http.StatusInternalServerError)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
-
 }
 
 func createRecord(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
 	}
+	if Raft.redirectToLeader(w, r) {
+		return
+	}
 
 	var newRecord Record
 	if err := json.NewDecoder(r.Body).Decode(&newRecord); err != nil {
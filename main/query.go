@@ -0,0 +1,313 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Query describes a GET /records request: how many rows to skip/return and
+// an optional filter predicate.
+type Query struct {
+	Offset int64
+	Limit  int64
+	Where  *Predicate
+}
+
+// Predicate is one parsed filter expression, e.g. `IntValue>10`.  Exactly
+// one of IntVal/BoolVal/StrVal/TimeVal is meaningful, picked by Field.
+type Predicate struct {
+	Field   string
+	Op      string
+	IntVal  int64
+	BoolVal bool
+	StrVal  string
+	TimeVal time.Time
+}
+
+var predicateExpr = regexp.MustCompile(`^(\w+)(>=|<=|>|<|=|~)(.+)$`)
+
+// ParsePredicate parses a filter expression of the form `Field<op>Value`.
+// Supported operators are `>`, `>=`, `<`, `<=`, `=` and the prefix match
+// `~`; which value type Value is parsed as depends on Field.
+func ParsePredicate(expr string) (*Predicate, error) {
+	m := predicateExpr.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("invalid filter expression %q", expr)
+	}
+	field, op, value := m[1], m[2], m[3]
+
+	p := &Predicate{Field: field, Op: op}
+	switch field {
+	case "IntValue", "ID":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid int value in %q: %w", expr, err)
+		}
+		p.IntVal = n
+	case "BoolValue":
+		if op != "=" {
+			return nil, fmt.Errorf("BoolValue only supports =, got %q", op)
+		}
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool value in %q: %w", expr, err)
+		}
+		p.BoolVal = b
+	case "TimeValue":
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid time value in %q: %w", expr, err)
+		}
+		p.TimeVal = t
+	case "StrValue":
+		if op != "~" && op != "=" {
+			return nil, fmt.Errorf("StrValue only supports ~ and =, got %q", op)
+		}
+		p.StrVal = value
+	default:
+		return nil, fmt.Errorf("unknown filter field %q", field)
+	}
+	return p, nil
+}
+
+// Match reports whether record satisfies the predicate.
+func (p *Predicate) Match(record Record) bool {
+	switch p.Field {
+	case "IntValue":
+		return compareInt64(record.IntValue, p.Op, p.IntVal)
+	case "ID":
+		return compareInt64(record.ID, p.Op, p.IntVal)
+	case "BoolValue":
+		return record.BoolValue == p.BoolVal
+	case "TimeValue":
+		return compareTime(record.TimeValue, p.Op, p.TimeVal)
+	case "StrValue":
+		if p.Op == "~" {
+			return strings.HasPrefix(record.StrValue, p.StrVal)
+		}
+		return record.StrValue == p.StrVal
+	default:
+		return false
+	}
+}
+
+func compareInt64(a int64, op string, b int64) bool {
+	switch op {
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case "=":
+		return a == b
+	}
+	return false
+}
+
+func compareTime(a time.Time, op string, b time.Time) bool {
+	switch op {
+	case ">":
+		return a.After(b)
+	case ">=":
+		return a.After(b) || a.Equal(b)
+	case "<":
+		return a.Before(b)
+	case "<=":
+		return a.Before(b) || a.Equal(b)
+	case "=":
+		return a.Equal(b)
+	}
+	return false
+}
+
+// isRangeable reports whether op can be answered by a sorted index lookup.
+func isRangeable(op string) bool {
+	switch op {
+	case ">", ">=", "<", "<=", "=":
+		return true
+	default:
+		return false
+	}
+}
+
+// CreateIndex builds a secondary index over field (one of the keys in
+// indexableFields), so List can satisfy a range predicate on it in
+// O(log n + k) instead of scanning every record.
+func (f *RecordRepositoryImpl) CreateIndex(field string) error {
+	if _, ok := indexableFields[field]; !ok {
+		return fmt.Errorf("field %q cannot be indexed", field)
+	}
+
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+	if f.indexes == nil {
+		f.indexes = make(map[string]*fieldIndex)
+	}
+	f.indexes[field] = newFieldIndex(field, f.allRecords())
+	return nil
+}
+
+// updateIndexes keeps every live secondary index in sync with a mutation.
+// old is nil for an add, updated is nil for a delete.
+func (f *RecordRepositoryImpl) updateIndexes(old, updated *Record) {
+	f.indexMu.Lock()
+	defer f.indexMu.Unlock()
+	for _, idx := range f.indexes {
+		switch {
+		case old == nil:
+			idx.insert(*updated)
+		case updated == nil:
+			idx.remove(*old)
+		default:
+			idx.update(*old, *updated)
+		}
+	}
+}
+
+// List returns a page of records matching query.Where (or every record, if
+// nil), ordered by ID, plus the offset to resume from (-1 once exhausted).
+// When the predicate is a range/equality on an indexed field, the lookup
+// uses that index instead of a full scan.
+func (f *RecordRepositoryImpl) List(query Query) ([]Record, int64, error) {
+	matches := []Record{}
+	usedIndex := false
+
+	if query.Where != nil && isRangeable(query.Where.Op) {
+		f.indexMu.RLock()
+		idx, ok := f.indexes[query.Where.Field]
+		f.indexMu.RUnlock()
+		if ok {
+			usedIndex = true
+			ids := idx.rangeIDs(query.Where.Op, indexKeyOf(query.Where))
+			for _, id := range ids {
+				record, err := f.read(id)
+				if err != nil {
+					continue
+				}
+				if query.Where.Match(record) {
+					matches = append(matches, record)
+				}
+			}
+		}
+	}
+
+	if !usedIndex {
+		for _, record := range f.allRecords() {
+			if query.Where == nil || query.Where.Match(record) {
+				matches = append(matches, record)
+			}
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool { return matches[i].ID < matches[j].ID })
+
+	offset := query.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= int64(len(matches)) {
+		return []Record{}, -1, nil
+	}
+
+	end := int64(len(matches))
+	if query.Limit > 0 && offset+query.Limit < end {
+		end = offset + query.Limit
+	}
+
+	page := matches[offset:end]
+	nextOffset := int64(-1)
+	if end < int64(len(matches)) {
+		nextOffset = end
+	}
+	return page, nextOffset, nil
+}
+
+// indexKeyOf projects a Predicate's value onto the same int64 key space
+// fieldIndex sorts on.
+func indexKeyOf(p *Predicate) int64 {
+	switch p.Field {
+	case "TimeValue":
+		return p.TimeVal.UnixNano()
+	case "BoolValue":
+		if p.BoolVal {
+			return 1
+		}
+		return 0
+	default:
+		return p.IntVal
+	}
+}
+
+// parseListQuery reads offset/limit/where from the request's query string.
+func parseListQuery(r *http.Request) (Query, error) {
+	q := r.URL.Query()
+	query := Query{}
+
+	if s := q.Get("offset"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid offset: %w", err)
+		}
+		query.Offset = n
+	}
+	if s := q.Get("limit"); s != "" {
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return Query{}, fmt.Errorf("invalid limit: %w", err)
+		}
+		query.Limit = n
+	}
+	if s := q.Get("where"); s != "" {
+		pred, err := ParsePredicate(s)
+		if err != nil {
+			return Query{}, err
+		}
+		query.Where = pred
+	}
+	return query, nil
+}
+
+// recordsHandler dispatches /records: GET lists/paginates, POST creates.
+func recordsHandler(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		listRecords(w, r)
+	case http.MethodPost:
+		createRecord(w, r)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func listRecords(w http.ResponseWriter, r *http.Request) {
+	if consistencyFor(r) == "leader" && Raft.redirectToLeader(w, r) {
+		return
+	}
+
+	query, err := parseListQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	records, nextOffset, err := FileDB.List(query)
+	if err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("X-Next-Offset", strconv.FormatInt(nextOffset, 10))
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(records); err != nil {
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParsePredicate(t *testing.T) {
+	cases := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"IntValue>10", false},
+		{"IntValue>=10", false},
+		{"ID<5", false},
+		{"BoolValue=true", false},
+		{"BoolValue>true", true},
+		{"TimeValue>=2024-01-01T00:00:00Z", false},
+		{"TimeValue=not-a-time", true},
+		{"StrValue~foo", false},
+		{"StrValue=foo", false},
+		{"StrValue>foo", true},
+		{"Unknown=1", true},
+		{"not an expression", true},
+	}
+	for _, c := range cases {
+		_, err := ParsePredicate(c.expr)
+		if (err != nil) != c.wantErr {
+			t.Errorf("ParsePredicate(%q) error = %v, wantErr %v", c.expr, err, c.wantErr)
+		}
+	}
+}
+
+func TestPredicateMatch(t *testing.T) {
+	record := Record{ID: 3, IntValue: 42, StrValue: "prefixed-value", BoolValue: true}
+
+	cases := []struct {
+		expr string
+		want bool
+	}{
+		{"IntValue>10", true},
+		{"IntValue<10", false},
+		{"ID=3", true},
+		{"BoolValue=true", true},
+		{"BoolValue=false", false},
+		{"StrValue~prefixed", true},
+		{"StrValue~other", false},
+		{"StrValue=prefixed-value", true},
+	}
+	for _, c := range cases {
+		pred, err := ParsePredicate(c.expr)
+		if err != nil {
+			t.Fatalf("ParsePredicate(%q): %v", c.expr, err)
+		}
+		if got := pred.Match(record); got != c.want {
+			t.Errorf("Match(%q) = %v, want %v", c.expr, got, c.want)
+		}
+	}
+}
+
+// newListTestRepo seeds a fresh, on-disk-backed repository with IntValue
+// 0..n-1 so range queries have a predictable key order to check against.
+func newListTestRepo(t *testing.T, n int) *RecordRepositoryImpl {
+	t.Helper()
+	dir := t.TempDir()
+	repo := NewRecordRepository(filepath.Join(dir, "records.bin"), nil)
+	if err := repo.loadDb(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		if _, err := repo.add(Record{IntValue: int64(i), TimeValue: time.Now()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return repo
+}
+
+func TestListFullScanAndIndexAgree(t *testing.T) {
+	repo := newListTestRepo(t, 20)
+
+	pred, err := ParsePredicate("IntValue>=10")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scanned, _, err := repo.List(Query{Where: pred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scanned) != 10 {
+		t.Fatalf("full scan: got %d matches, want 10", len(scanned))
+	}
+
+	if err := repo.CreateIndex("IntValue"); err != nil {
+		t.Fatal(err)
+	}
+	indexed, _, err := repo.List(Query{Where: pred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(indexed) != len(scanned) {
+		t.Fatalf("indexed lookup: got %d matches, want %d", len(indexed), len(scanned))
+	}
+	for i := range indexed {
+		if indexed[i].ID != scanned[i].ID {
+			t.Fatalf("indexed[%d].ID = %d, scanned[%d].ID = %d", i, indexed[i].ID, i, scanned[i].ID)
+		}
+	}
+}
+
+func TestListIndexStaysCurrentAfterMutation(t *testing.T) {
+	repo := newListTestRepo(t, 5)
+	if err := repo.CreateIndex("IntValue"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := repo.update(Record{IntValue: 99, TimeValue: time.Now()}, 3); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.delete(1); err != nil {
+		t.Fatal(err)
+	}
+
+	pred, err := ParsePredicate("IntValue>=0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches, _, err := repo.List(Query{Where: pred})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 4 {
+		t.Fatalf("got %d matches after delete, want 4", len(matches))
+	}
+	for _, r := range matches {
+		if r.ID == 3 && r.IntValue != 99 {
+			t.Fatalf("record 3 has IntValue %d, want 99 (index should reflect the update)", r.IntValue)
+		}
+		if r.ID == 1 {
+			t.Fatalf("deleted record 1 still present in index-backed List")
+		}
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	repo := newListTestRepo(t, 5)
+
+	var seen []int64
+	offset := int64(0)
+	for {
+		page, next, err := repo.List(Query{Offset: offset, Limit: 2})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range page {
+			seen = append(seen, r.ID)
+		}
+		if next == -1 {
+			break
+		}
+		if len(page) != 2 {
+			t.Fatalf("non-final page has %d records, want 2", len(page))
+		}
+		offset = next
+	}
+
+	if len(seen) != 5 {
+		t.Fatalf("paginated through %d records, want 5", len(seen))
+	}
+	for i := 1; i < len(seen); i++ {
+		if seen[i] <= seen[i-1] {
+			t.Fatalf("pagination order not monotonic: %v", seen)
+		}
+	}
+
+	page, next, err := repo.List(Query{Offset: 100, Limit: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(page) != 0 || next != -1 {
+		t.Fatalf("out-of-range offset: got %d records, next %d, want 0 records, next -1", len(page), next)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newBenchServer wires up the same routes main() registers, backed by a
+// fresh RecordRepositoryImpl rooted in a temp dir, so the benchmark below
+// exercises the real HTTP handlers end to end.
+func newBenchServer(b *testing.B) *httptest.Server {
+	b.Helper()
+	dir := b.TempDir()
+	FileDB = *NewRecordRepository(filepath.Join(dir, "records.bin"), nil)
+	if err := FileDB.loadDb(); err != nil {
+		b.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/records", recordsHandler)
+	mux.HandleFunc("/records/", recordByIDHandler)
+	return httptest.NewServer(mux)
+}
+
+// BenchmarkConcurrentCRUD drives concurrent POST/GET/PUT/DELETE traffic
+// against a shared pool of records to demonstrate that the sharded map
+// scales with GOMAXPROCS instead of serializing on one global mutex.
+func BenchmarkConcurrentCRUD(b *testing.B) {
+	server := newBenchServer(b)
+	defer server.Close()
+	client := server.Client()
+
+	const seeded = 256
+	ids := make([]int64, seeded)
+	for i := range ids {
+		id, err := FileDB.add(Record{IntValue: int64(i), StrValue: "seed"})
+		if err != nil {
+			b.Fatal(err)
+		}
+		ids[i] = id
+	}
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			id := ids[i%len(ids)]
+			i++
+
+			getResp, err := client.Get(fmt.Sprintf("%s/records/%d", server.URL, id))
+			if err != nil {
+				b.Fatal(err)
+			}
+			getResp.Body.Close()
+
+			body, err := json.Marshal(Record{IntValue: int64(i), StrValue: "updated", TimeValue: time.Now()})
+			if err != nil {
+				b.Fatal(err)
+			}
+			putReq, err := http.NewRequest(http.MethodPut, fmt.Sprintf("%s/records/%d", server.URL, id), bytes.NewReader(body))
+			if err != nil {
+				b.Fatal(err)
+			}
+			putResp, err := client.Do(putReq)
+			if err != nil {
+				b.Fatal(err)
+			}
+			putResp.Body.Close()
+
+			newBody, err := json.Marshal(Record{IntValue: int64(i), StrValue: "scratch"})
+			if err != nil {
+				b.Fatal(err)
+			}
+			postResp, err := client.Post(server.URL+"/records", "application/json", bytes.NewReader(newBody))
+			if err != nil {
+				b.Fatal(err)
+			}
+			postResp.Body.Close()
+
+			scratchID, err := FileDB.add(Record{IntValue: int64(i), StrValue: "to-delete"})
+			if err != nil {
+				b.Fatal(err)
+			}
+			delReq, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("%s/records/%d", server.URL, scratchID), nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+			delResp, err := client.Do(delReq)
+			if err != nil {
+				b.Fatal(err)
+			}
+			delResp.Body.Close()
+		}
+	})
+}
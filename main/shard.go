@@ -0,0 +1,66 @@
+package main
+
+import "sync"
+
+// numShards is the number of independent record shards. Reads only ever
+// take an RLock on the one shard a given ID hashes to, so unrelated records
+// no longer contend on a single global mutex.
+const numShards = 64
+
+type shard struct {
+	mu      sync.RWMutex
+	records map[int64]Record
+}
+
+func newShards() [numShards]*shard {
+	var shards [numShards]*shard
+	for i := range shards {
+		shards[i] = &shard{records: make(map[int64]Record)}
+	}
+	return shards
+}
+
+func shardFor(shards [numShards]*shard, id int64) *shard {
+	return shards[uint64(id)%numShards]
+}
+
+// idAllocator hands out record IDs. Deleted IDs go on a free list so they
+// get reused the same way the old slice-position scheme intended, but
+// unlike that scheme the ID it returns is never ambiguous with a slice
+// index - it's the one and only identifier for the record.
+type idAllocator struct {
+	mu   sync.Mutex
+	next int64
+	free []int64
+}
+
+// allocate returns a free ID if one is available, otherwise the next unused
+// one.
+func (a *idAllocator) allocate() int64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if n := len(a.free); n > 0 {
+		id := a.free[n-1]
+		a.free = a.free[:n-1]
+		return id
+	}
+	a.next++
+	return a.next
+}
+
+// release returns id to the free list after a delete.
+func (a *idAllocator) release(id int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.free = append(a.free, id)
+}
+
+// observe records that id is in use, bumping the counter so a later
+// allocate() never hands out an ID already seen on load.
+func (a *idAllocator) observe(id int64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if id > a.next {
+		a.next = id
+	}
+}